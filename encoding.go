@@ -0,0 +1,208 @@
+package intset
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*IntSet)(nil)
+	_ encoding.BinaryUnmarshaler = (*IntSet)(nil)
+	_ json.Marshaler             = (*IntSet)(nil)
+	_ json.Unmarshaler           = (*IntSet)(nil)
+	_ io.WriterTo                = (*IntSet)(nil)
+	_ io.ReaderFrom              = (*IntSet)(nil)
+)
+
+// binaryWordSize is the word size, in bits, that a set is normalized to
+// before being written, so the format round-trips across platforms.
+const binaryWordSize = 64
+
+// normalizedWords repacks s.words into 64-bit words, independent of N.
+func (s *IntSet) normalizedWords() []uint64 {
+	if N == 64 {
+		words64 := make([]uint64, len(s.words))
+		for i, word := range s.words {
+			words64[i] = uint64(word)
+		}
+		return words64
+	}
+	words64 := make([]uint64, (len(s.words)+1)/2)
+	for i, word := range s.words {
+		words64[i/2] |= uint64(word) << uint((i%2)*32)
+	}
+	return words64
+}
+
+// wordsFromNormalized is the inverse of normalizedWords, dropping any
+// trailing all-zero words.
+func wordsFromNormalized(words64 []uint64) []uint {
+	var words []uint
+	if N == 64 {
+		words = make([]uint, len(words64))
+		for i, word := range words64 {
+			words[i] = uint(word)
+		}
+	} else {
+		words = make([]uint, 0, len(words64)*2)
+		for _, word := range words64 {
+			words = append(words, uint(word), uint(word>>32))
+		}
+	}
+	for len(words) > 0 && words[len(words)-1] == 0 {
+		words = words[:len(words)-1]
+	}
+	return words
+}
+
+// WriteTo writes s in a portable binary format. It satisfies io.WriterTo.
+func (s *IntSet) WriteTo(w io.Writer) (int64, error) {
+	words64 := s.normalizedWords()
+	var written int64
+
+	n, err := w.Write([]byte{binaryWordSize})
+	written += int64(n)
+	if err != nil {
+		return written, err
+	}
+
+	var vbuf [binary.MaxVarintLen64]byte
+	vn := binary.PutUvarint(vbuf[:], uint64(len(words64)))
+	n, err = w.Write(vbuf[:vn])
+	written += int64(n)
+	if err != nil {
+		return written, err
+	}
+
+	var wbuf [8]byte
+	for _, word := range words64 {
+		binary.LittleEndian.PutUint64(wbuf[:], word)
+		n, err = w.Write(wbuf[:])
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// ReadFrom reads the format written by WriteTo. It satisfies io.ReaderFrom.
+func (s *IntSet) ReadFrom(r io.Reader) (int64, error) {
+	var read int64
+
+	var hdr [1]byte
+	n, err := io.ReadFull(r, hdr[:])
+	read += int64(n)
+	if err != nil {
+		return read, err
+	}
+	if hdr[0] != binaryWordSize {
+		return read, fmt.Errorf("intset: unsupported word size %d in binary data", hdr[0])
+	}
+
+	count, vn, err := readUvarint(r)
+	read += vn
+	if err != nil {
+		return read, err
+	}
+	if count > maxBinaryWords {
+		return read, fmt.Errorf("intset: word count %d in binary data exceeds maximum of %d", count, maxBinaryWords)
+	}
+
+	// words64 grows as words are actually read, rather than preallocating
+	// to the untrusted count.
+	words64 := make([]uint64, 0, minUint64(count, 1024))
+	var wbuf [8]byte
+	for i := uint64(0); i < count; i++ {
+		n, err := io.ReadFull(r, wbuf[:])
+		read += int64(n)
+		if err != nil {
+			return read, err
+		}
+		words64 = append(words64, binary.LittleEndian.Uint64(wbuf[:]))
+	}
+
+	s.words = wordsFromNormalized(words64)
+	return read, nil
+}
+
+// maxBinaryWords bounds the word count accepted from a binary header.
+const maxBinaryWords = 1 << 24
+
+func minUint64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// readUvarint decodes a uvarint from r one byte at a time, since r is not
+// guaranteed to implement io.ByteReader, and reports the number of bytes
+// consumed.
+func readUvarint(r io.Reader) (uint64, int64, error) {
+	var x uint64
+	var shift uint
+	var n int64
+	var buf [1]byte
+	for {
+		if n == binary.MaxVarintLen64 {
+			return 0, n, fmt.Errorf("intset: varint overflows uint64")
+		}
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return 0, n, err
+		}
+		n++
+		b := buf[0]
+		if b < 0x80 {
+			return x | uint64(b)<<shift, n, nil
+		}
+		x |= uint64(b&0x7f) << shift
+		shift += 7
+	}
+}
+
+// MarshalBinary encodes s in the portable format written by WriteTo. It
+// satisfies encoding.BinaryMarshaler.
+func (s *IntSet) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes data written by MarshalBinary. It satisfies
+// encoding.BinaryUnmarshaler.
+func (s *IntSet) UnmarshalBinary(data []byte) error {
+	_, err := s.ReadFrom(bytes.NewReader(data))
+	return err
+}
+
+// MarshalJSON encodes s as a sorted JSON array of its elements. It
+// satisfies json.Marshaler.
+func (s *IntSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Elems())
+}
+
+// UnmarshalJSON decodes a JSON array of elements written by MarshalJSON,
+// replacing s's contents. It satisfies json.Unmarshaler.
+func (s *IntSet) UnmarshalJSON(data []byte) error {
+	var elems []int
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return err
+	}
+	for _, x := range elems {
+		if x < 0 {
+			return fmt.Errorf("intset: negative element %d in JSON data", x)
+		}
+	}
+	s.words = nil
+	for _, x := range elems {
+		s.Add(x)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package intset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+func TestIntSetBinaryRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		elems []int
+	}{
+		{"empty", nil},
+		{"small", []int{1, 2, 3}},
+		{"sparse spread", []int{0, 1, 63, 64, 65, 1000000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s IntSet
+			s.AddAll(tt.elems...)
+
+			data, err := s.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary: %v", err)
+			}
+			var got IntSet
+			if err := got.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary: %v", err)
+			}
+			if got.String() != s.String() {
+				t.Fatalf("binary round-trip: got %v, want %v", got.String(), s.String())
+			}
+
+			var buf bytes.Buffer
+			if _, err := s.WriteTo(&buf); err != nil {
+				t.Fatalf("WriteTo: %v", err)
+			}
+			var got2 IntSet
+			if _, err := got2.ReadFrom(&buf); err != nil {
+				t.Fatalf("ReadFrom: %v", err)
+			}
+			if got2.String() != s.String() {
+				t.Fatalf("WriteTo/ReadFrom round-trip: got %v, want %v", got2.String(), s.String())
+			}
+		})
+	}
+}
+
+func TestIntSetJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		elems []int
+	}{
+		{"empty", nil},
+		{"small", []int{3, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s IntSet
+			s.AddAll(tt.elems...)
+
+			data, err := json.Marshal(&s)
+			if err != nil {
+				t.Fatalf("json.Marshal: %v", err)
+			}
+			var got IntSet
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+			if got.String() != s.String() {
+				t.Fatalf("JSON round-trip: got %v, want %v", got.String(), s.String())
+			}
+		})
+	}
+}
+
+// TestIntSetReadFromRejectsHugeCount ensures a header claiming an
+// enormous word count fails fast rather than attempting a huge
+// allocation, since count is read straight off an untrusted stream.
+func TestIntSetReadFromRejectsHugeCount(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteByte(binaryWordSize)
+	var vbuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(vbuf[:], 1<<62)
+	buf.Write(vbuf[:n])
+
+	var s IntSet
+	if _, err := s.ReadFrom(&buf); err == nil {
+		t.Fatalf("ReadFrom with a huge word count succeeded, want an error")
+	}
+}
+
+// TestIntSetUnmarshalJSONRejectsNegative ensures a negative element in the
+// JSON array is reported as an error instead of panicking on an
+// out-of-range word index.
+func TestIntSetUnmarshalJSONRejectsNegative(t *testing.T) {
+	var s IntSet
+	if err := s.UnmarshalJSON([]byte("[-64]")); err == nil {
+		t.Fatalf("UnmarshalJSON with a negative element succeeded, want an error")
+	}
+}
+
+// TestIntSetReadFromRejectsVarintOverflow ensures a stream of unterminated
+// continuation bytes is rejected rather than silently wrapping into a
+// bogus count.
+func TestIntSetReadFromRejectsVarintOverflow(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteByte(binaryWordSize)
+	for i := 0; i < binary.MaxVarintLen64+1; i++ {
+		buf.WriteByte(0x80)
+	}
+
+	var s IntSet
+	if _, err := s.ReadFrom(&buf); err == nil {
+		t.Fatalf("ReadFrom with an unterminated varint succeeded, want an error")
+	}
+}
@@ -4,6 +4,7 @@ package intset
 import (
 	"bytes"
 	"fmt"
+	"math/bits"
 )
 
 // N is 32 or 64 on 32-bit or 64-bit platform respectively.
@@ -37,34 +38,78 @@ func (s *IntSet) AddAll(vals ...int) {
 	}
 }
 
-// UnionWith sets s to the union of s and t.
-func (s *IntSet) UnionWith(t *IntSet) {
-	for i, tword := range t.words {
-		if i < len(s.words) {
-			s.words[i] |= tword
-		} else {
-			s.words = append(s.words, tword)
+// UnionWith sets s to the union of s and t. Unlike IntersectWith and
+// DifferenceWith, it only supports t being an *IntSet or *SparseIntSet —
+// the two concrete types in this package — and panics for any other Set.
+func (s *IntSet) UnionWith(t Set) {
+	switch tt := t.(type) {
+	case *IntSet:
+		for i, tword := range tt.words {
+			if i < len(s.words) {
+				s.words[i] |= tword
+			} else {
+				s.words = append(s.words, tword)
+			}
 		}
+	case *SparseIntSet:
+		for b := tt.head; b != nil; b = b.next {
+			for i, w := range b.bits {
+				for j := 0; j < N; j++ {
+					if w&(1<<uint(j)) != 0 {
+						s.Add(b.offset*bitsPerBlock + i*N + j)
+					}
+				}
+			}
+		}
+	default:
+		panic(fmt.Sprintf("intset: IntSet.UnionWith: unsupported Set implementation %T", t))
 	}
 }
 
-// IntersectWith sets s to the intersect of s and t.
-func (s *IntSet) IntersectWith(t *IntSet) {
-	for i := range t.words {
-		if i >= len(s.words) {
-			break
+// IntersectWith sets s to the intersect of s and t. t may be any Set:
+// membership in t is tested via t.Has, so this works regardless of t's
+// underlying representation.
+func (s *IntSet) IntersectWith(t Set) {
+	if tt, ok := t.(*IntSet); ok {
+		for i := range tt.words {
+			if i >= len(s.words) {
+				break
+			}
+			s.words[i] &= tt.words[i]
+		}
+		for i := len(tt.words); i < len(s.words); i++ {
+			s.words[i] = 0
+		}
+		return
+	}
+	for i, word := range s.words {
+		for j := 0; j < N; j++ {
+			if word&(1<<uint(j)) != 0 && !t.Has(i*N+j) {
+				s.words[i] &^= 1 << uint(j)
+			}
 		}
-		s.words[i] &= t.words[i]
 	}
 }
 
-// DifferenceWith sets s to the difference of s and t.
-func (s *IntSet) DifferenceWith(t *IntSet) {
-	for i := range t.words {
-		if i >= len(s.words) {
-			break
+// DifferenceWith sets s to the difference of s and t. t may be any Set:
+// membership in t is tested via t.Has, so this works regardless of t's
+// underlying representation.
+func (s *IntSet) DifferenceWith(t Set) {
+	if tt, ok := t.(*IntSet); ok {
+		for i := range tt.words {
+			if i >= len(s.words) {
+				break
+			}
+			s.words[i] &^= tt.words[i]
+		}
+		return
+	}
+	for i, word := range s.words {
+		for j := 0; j < N; j++ {
+			if word&(1<<uint(j)) != 0 && t.Has(i*N+j) {
+				s.words[i] &^= 1 << uint(j)
+			}
 		}
-		s.words[i] &^= t.words[i]
 	}
 }
 
@@ -79,24 +124,273 @@ func (s *IntSet) SymmetricDifference(t *IntSet) {
 	}
 }
 
+// FlipRange toggles membership of every value in [from, to) using
+// word-parallel XOR: interior words that lie entirely within the range
+// are XOR'd with a full mask, while the first and last words are XOR'd
+// with a mask covering only their in-range bits.
+func (s *IntSet) FlipRange(from, to int) {
+	if from >= to {
+		return
+	}
+	firstWord, lastWord := from/N, (to-1)/N
+	for lastWord >= len(s.words) {
+		s.words = append(s.words, 0)
+	}
+	for word := firstWord; word <= lastWord; word++ {
+		lo, hi := 0, N
+		if word == firstWord {
+			lo = from % N
+		}
+		if word == lastWord {
+			hi = to - word*N
+		}
+		mask := uint(1)<<uint(hi) - 1
+		mask &^= uint(1)<<uint(lo) - 1
+		s.words[word] ^= mask
+	}
+}
+
+// ComplementWithin sets s to universe∖s, the complement of s relative to
+// universe. A true complement of a sparse set would be infinite, so
+// callers must supply the universe of values under consideration.
+func (s *IntSet) ComplementWithin(universe *IntSet) {
+	for len(s.words) < len(universe.words) {
+		s.words = append(s.words, 0)
+	}
+	for i := range universe.words {
+		s.words[i] = universe.words[i] &^ s.words[i]
+	}
+	if len(s.words) > len(universe.words) {
+		s.words = s.words[:len(universe.words)]
+	}
+}
+
+// IsEmpty reports whether the set has no elements.
+func (s *IntSet) IsEmpty() bool {
+	for _, word := range s.words {
+		if word != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Any reports whether the set has at least one element.
+func (s *IntSet) Any() bool {
+	return !s.IsEmpty()
+}
+
+// None reports whether the set has no elements.
+func (s *IntSet) None() bool {
+	return s.IsEmpty()
+}
+
+// All reports whether s contains every element of universe. Unlike
+// Any/None, "all" has no fixed meaning for a set over an unbounded
+// domain, so callers must supply the universe of values under
+// consideration.
+func (s *IntSet) All(universe *IntSet) bool {
+	return universe.SubsetOf(s)
+}
+
+// Equals reports whether s and t contain the same elements.
+func (s *IntSet) Equals(t *IntSet) bool {
+	for i := 0; i < len(s.words) || i < len(t.words); i++ {
+		var sword, tword uint
+		if i < len(s.words) {
+			sword = s.words[i]
+		}
+		if i < len(t.words) {
+			tword = t.words[i]
+		}
+		if sword != tword {
+			return false
+		}
+	}
+	return true
+}
+
+// SubsetOf reports whether every element of s is also an element of t,
+// i.e. whether s∖t is empty.
+func (s *IntSet) SubsetOf(t *IntSet) bool {
+	for i, sword := range s.words {
+		var tword uint
+		if i < len(t.words) {
+			tword = t.words[i]
+		}
+		if sword&^tword != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersects reports whether s and t have any element in common. It is
+// much cheaper than computing s.Intersection(t) just to test IsEmpty,
+// since it stops at the first overlapping word.
+func (s *IntSet) Intersects(t *IntSet) bool {
+	for i := 0; i < len(s.words) && i < len(t.words); i++ {
+		if s.words[i]&t.words[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// UnionCount returns |s∪t| without allocating an intermediate set.
+func (s *IntSet) UnionCount(t *IntSet) int {
+	n := 0
+	for i := 0; i < len(s.words) || i < len(t.words); i++ {
+		var sword, tword uint
+		if i < len(s.words) {
+			sword = s.words[i]
+		}
+		if i < len(t.words) {
+			tword = t.words[i]
+		}
+		n += bits.OnesCount(sword | tword)
+	}
+	return n
+}
+
+// IntersectionCount returns |s∩t| without allocating an intermediate set.
+func (s *IntSet) IntersectionCount(t *IntSet) int {
+	n := 0
+	for i := 0; i < len(s.words) && i < len(t.words); i++ {
+		n += bits.OnesCount(s.words[i] & t.words[i])
+	}
+	return n
+}
+
+// DifferenceCount returns |s∖t| without allocating an intermediate set.
+func (s *IntSet) DifferenceCount(t *IntSet) int {
+	n := 0
+	for i, sword := range s.words {
+		var tword uint
+		if i < len(t.words) {
+			tword = t.words[i]
+		}
+		n += bits.OnesCount(sword &^ tword)
+	}
+	return n
+}
+
+// SymmetricDifferenceCount returns |s△t| without allocating an
+// intermediate set.
+func (s *IntSet) SymmetricDifferenceCount(t *IntSet) int {
+	n := 0
+	for i := 0; i < len(s.words) || i < len(t.words); i++ {
+		var sword, tword uint
+		if i < len(s.words) {
+			sword = s.words[i]
+		}
+		if i < len(t.words) {
+			tword = t.words[i]
+		}
+		n += bits.OnesCount(sword ^ tword)
+	}
+	return n
+}
+
+// Union returns a new set holding the union of s and t, leaving both
+// unmodified.
+func (s *IntSet) Union(t *IntSet) *IntSet {
+	u := s.Copy()
+	u.UnionWith(t)
+	return u
+}
+
+// Intersection returns a new set holding the intersection of s and t,
+// leaving both unmodified.
+func (s *IntSet) Intersection(t *IntSet) *IntSet {
+	u := s.Copy()
+	u.IntersectWith(t)
+	return u
+}
+
+// Difference returns a new set holding the difference of s and t, leaving
+// both unmodified.
+func (s *IntSet) Difference(t *IntSet) *IntSet {
+	u := s.Copy()
+	u.DifferenceWith(t)
+	return u
+}
+
+// SymmetricDiff returns a new set holding the symmetric difference of s and
+// t, leaving both unmodified.
+func (s *IntSet) SymmetricDiff(t *IntSet) *IntSet {
+	u := s.Copy()
+	u.SymmetricDifference(t)
+	return u
+}
+
+// Set adds x to the set and returns s, so that calls may be chained, e.g.
+// s.Set(10).Set(11).Union(t).
+func (s *IntSet) Set(x int) *IntSet {
+	s.Add(x)
+	return s
+}
+
+// Unset removes x from the set and returns s, so that calls may be
+// chained.
+func (s *IntSet) Unset(x int) *IntSet {
+	s.Remove(x)
+	return s
+}
+
+// Flip toggles the membership of x in the set and returns s, so that
+// calls may be chained.
+func (s *IntSet) Flip(x int) *IntSet {
+	if s.Has(x) {
+		s.Remove(x)
+	} else {
+		s.Add(x)
+	}
+	return s
+}
+
+// NextSet returns the smallest element of the set that is >= from, and
+// reports whether such an element exists.
+func (s *IntSet) NextSet(from int) (int, bool) {
+	if from < 0 {
+		from = 0
+	}
+	word, bit := from/N, uint(from%N)
+	if word >= len(s.words) {
+		return 0, false
+	}
+	if masked := s.words[word] &^ (1<<bit - 1); masked != 0 {
+		return word*N + bits.TrailingZeros(masked), true
+	}
+	for word++; word < len(s.words); word++ {
+		if s.words[word] != 0 {
+			return word*N + bits.TrailingZeros(s.words[word]), true
+		}
+	}
+	return 0, false
+}
+
+// Visit calls f on each element of the set in ascending order, stopping
+// early if f returns false.
+func (s *IntSet) Visit(f func(int) bool) {
+	for x, ok := s.NextSet(0); ok; x, ok = s.NextSet(x + 1) {
+		if !f(x) {
+			return
+		}
+	}
+}
+
 // String returns the set as a string of the form "{1 2 3}".
 func (s IntSet) String() string {
 	var buf bytes.Buffer
 	buf.WriteByte('{')
-	for i, word := range s.words {
-		if word == 0 {
-			continue
-		}
-		for j := 0; j < N; j++ {
-			if word&(1<<j) == 0 {
-				continue
-			}
-			if buf.Len() > len("{") {
-				buf.WriteByte(' ')
-			}
-			fmt.Fprintf(&buf, "%d", i*N+j)
+	(&s).Visit(func(x int) bool {
+		if buf.Len() > len("{") {
+			buf.WriteByte(' ')
 		}
-	}
+		fmt.Fprintf(&buf, "%d", x)
+		return true
+	})
 	buf.WriteByte('}')
 	return buf.String()
 }
@@ -105,14 +399,7 @@ func (s IntSet) String() string {
 func (s *IntSet) Len() int {
 	n := 0
 	for _, word := range s.words {
-		if word == 0 {
-			continue
-		}
-		for j := 0; j < N; j++ {
-			if word&(1<<j) != 0 {
-				n++
-			}
-		}
+		n += bits.OnesCount(word)
 	}
 	return n
 }
@@ -141,13 +428,10 @@ func (s *IntSet) Copy() *IntSet {
 
 // Elems return the set as a slice of int.
 func (s *IntSet) Elems() []int {
-	var res []int
-	for i, word := range s.words {
-		for j := 0; j < N; j++ {
-			if word&(1<<j) != 0 {
-				res = append(res, i*N+j)
-			}
-		}
-	}
+	res := make([]int, 0, s.Len())
+	s.Visit(func(x int) bool {
+		res = append(res, x)
+		return true
+	})
 	return res
 }
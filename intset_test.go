@@ -0,0 +1,308 @@
+package intset
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestIntSetNonMutatingCombinatorsLeaveOperandsUnchanged(t *testing.T) {
+	var s, u IntSet
+	s.AddAll(1, 2, 3)
+	u.AddAll(2, 3, 4)
+	sBefore, uBefore := s.String(), u.String()
+
+	if got, want := s.Union(&u).String(), "{1 2 3 4}"; got != want {
+		t.Errorf("Union = %v, want %v", got, want)
+	}
+	if got, want := s.Intersection(&u).String(), "{2 3}"; got != want {
+		t.Errorf("Intersection = %v, want %v", got, want)
+	}
+	if got, want := s.Difference(&u).String(), "{1}"; got != want {
+		t.Errorf("Difference = %v, want %v", got, want)
+	}
+	if got, want := s.SymmetricDiff(&u).String(), "{1 4}"; got != want {
+		t.Errorf("SymmetricDiff = %v, want %v", got, want)
+	}
+	if s.String() != sBefore || u.String() != uBefore {
+		t.Fatalf("a combinator mutated an operand: s=%v (want %v), u=%v (want %v)", s.String(), sBefore, u.String(), uBefore)
+	}
+}
+
+func TestIntSetChainableMutators(t *testing.T) {
+	var s IntSet
+	s.Set(10).Set(11).Unset(10).Flip(12)
+	if got, want := s.String(), "{11 12}"; got != want {
+		t.Errorf("chained mutators = %v, want %v", got, want)
+	}
+
+	var u IntSet
+	u.AddAll(12, 20)
+	if got, want := s.Set(13).Union(&u).String(), "{11 12 13 20}"; got != want {
+		t.Errorf("Union after chained Set = %v, want %v", got, want)
+	}
+}
+
+func TestIntSetEqualsMismatchedWordLengths(t *testing.T) {
+	var shortS, longT IntSet
+	shortS.Add(1)
+	longT.AddAll(1, 1000)
+	if shortS.Equals(&longT) || longT.Equals(&shortS) {
+		t.Errorf("Equals reported true for sets with different numbers of elements")
+	}
+
+	longT.Remove(1000)
+	if !shortS.Equals(&longT) || !longT.Equals(&shortS) {
+		t.Errorf("Equals reported false for equal sets, one of which has trailing zero words trimmed and one not")
+	}
+}
+
+func TestIntSetSubsetOfMismatchedWordLengths(t *testing.T) {
+	var shortS, longT IntSet
+	shortS.AddAll(1, 2)
+	longT.AddAll(1, 2, 1000)
+	if !shortS.SubsetOf(&longT) {
+		t.Errorf("SubsetOf reported false when s has fewer words than t but all its elements are present")
+	}
+
+	var longS, shortT IntSet
+	longS.AddAll(1, 1000)
+	shortT.Add(1)
+	if longS.SubsetOf(&shortT) {
+		t.Errorf("SubsetOf reported true when s has an element beyond t's word-slice length")
+	}
+}
+
+func TestIntSetIntersectsMismatchedWordLengths(t *testing.T) {
+	var overlapping, shorter IntSet
+	overlapping.AddAll(1, 1000)
+	shorter.Add(1)
+	if !overlapping.Intersects(&shorter) || !shorter.Intersects(&overlapping) {
+		t.Errorf("Intersects reported false for overlapping sets of different word-slice lengths")
+	}
+
+	var disjoint, other IntSet
+	disjoint.Add(1000)
+	other.Add(1)
+	if disjoint.Intersects(&other) || other.Intersects(&disjoint) {
+		t.Errorf("Intersects reported true for disjoint sets of different word-slice lengths")
+	}
+}
+
+func TestIntSetIsEmptyAnyNone(t *testing.T) {
+	var s IntSet
+	if !s.IsEmpty() || s.Any() || !s.None() {
+		t.Errorf("zero-value IntSet should be empty")
+	}
+
+	s.Add(5)
+	s.Remove(5)
+	if !s.IsEmpty() || s.Any() || !s.None() {
+		t.Errorf("IntSet with every bit cleared should report empty, even with allocated words")
+	}
+
+	s.Add(1000)
+	if s.IsEmpty() || !s.Any() || s.None() {
+		t.Errorf("non-empty IntSet reported empty")
+	}
+}
+
+func TestIntSetAll(t *testing.T) {
+	var universe IntSet
+	universe.AddAll(1, 2, 3)
+
+	var s IntSet
+	s.AddAll(1, 2, 3, 4)
+	if !s.All(&universe) {
+		t.Errorf("All(%v) = false for a set containing every element of universe", universe.String())
+	}
+
+	s.Remove(2)
+	if s.All(&universe) {
+		t.Errorf("All(%v) = true for a set missing an element of universe", universe.String())
+	}
+
+	var empty IntSet
+	if !empty.All(&empty) {
+		t.Errorf("All(empty) = false for the empty universe")
+	}
+}
+
+func TestIntSetNextSetWordBoundaries(t *testing.T) {
+	var firstBit IntSet
+	firstBit.Add(0)
+	if x, ok := firstBit.NextSet(0); !ok || x != 0 {
+		t.Errorf("NextSet(0) on a set containing bit 0 = %d, %v, want 0, true", x, ok)
+	}
+
+	var lastBitOfWord IntSet
+	lastBitOfWord.Add(N - 1)
+	if x, ok := lastBitOfWord.NextSet(0); !ok || x != N-1 {
+		t.Errorf("NextSet(0) = %d, %v, want %d, true", x, ok, N-1)
+	}
+	if _, ok := lastBitOfWord.NextSet(N); ok {
+		t.Errorf("NextSet(N) reported an element past the only set bit")
+	}
+
+	var acrossRollover IntSet
+	acrossRollover.Add(N) // first bit of word 1, to exercise the word-rollover loop
+	if x, ok := acrossRollover.NextSet(1); !ok || x != N {
+		t.Errorf("NextSet(1) across a word boundary = %d, %v, want %d, true", x, ok, N)
+	}
+	if x, ok := acrossRollover.NextSet(N); !ok || x != N {
+		t.Errorf("NextSet(N) = %d, %v, want %d, true", x, ok, N)
+	}
+
+	var empty IntSet
+	if _, ok := empty.NextSet(0); ok {
+		t.Errorf("NextSet on an empty set reported an element")
+	}
+}
+
+func TestIntSetVisitEarlyStop(t *testing.T) {
+	var s IntSet
+	s.AddAll(1, 2, 3, 4, 5)
+
+	var got []int
+	s.Visit(func(x int) bool {
+		got = append(got, x)
+		return x < 3
+	})
+	if want := []int{1, 2, 3}; !equalInts(got, want) {
+		t.Errorf("Visit with early stop visited %v, want %v", got, want)
+	}
+}
+
+func TestIntSetElemsLenStringAgainstReference(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	want := map[int]bool{}
+	var s IntSet
+	for i := 0; i < 200; i++ {
+		x := rng.Intn(5000)
+		want[x] = true
+		s.Add(x)
+	}
+	wantElems := sortedKeys(want)
+
+	if got := s.Elems(); !equalInts(got, wantElems) {
+		t.Fatalf("Elems = %v, want %v", got, wantElems)
+	}
+	if got, want := s.Len(), len(wantElems); got != want {
+		t.Fatalf("Len = %d, want %d", got, want)
+	}
+	if got, want := s.String(), formatElems(wantElems); got != want {
+		t.Fatalf("String = %v, want %v", got, want)
+	}
+}
+
+// formatElems renders xs the way IntSet.String does, e.g. "{1 2 3}".
+func formatElems(xs []int) string {
+	parts := make([]string, len(xs))
+	for i, x := range xs {
+		parts[i] = strconv.Itoa(x)
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+func TestIntSetFlipRangeAgainstReference(t *testing.T) {
+	tests := []struct {
+		name     string
+		elems    []int
+		from, to int
+	}{
+		{"confined to one word", []int{2, 5}, 1, 4},
+		{"from equal to to is a no-op", []int{1, 2}, 5, 5},
+		{"from greater than to is a no-op", []int{1, 2}, 5, 2},
+		{"spans a word boundary with partial edges", []int{N - 1, N, 2*N + 3}, N - 1, 2*N + 3},
+		{"starts and ends mid-word", []int{N + 2, N + 10}, N + 1, N + 20},
+		{"empty set, whole first word", nil, 0, N},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, want IntSet
+			got.AddAll(tt.elems...)
+			want.AddAll(tt.elems...)
+
+			got.FlipRange(tt.from, tt.to)
+			for x := tt.from; x < tt.to; x++ {
+				want.Flip(x)
+			}
+
+			if got.String() != want.String() {
+				t.Errorf("FlipRange(%d, %d) on %v = %v, want %v", tt.from, tt.to, tt.elems, got.String(), want.String())
+			}
+		})
+	}
+}
+
+func TestIntSetComplementWithinAgainstReference(t *testing.T) {
+	tests := []struct {
+		name            string
+		elems, universe []int
+	}{
+		{"s subset of universe", []int{1, 3}, []int{0, 1, 2, 3, 4}},
+		{"s has bits beyond universe's word-slice length", []int{1, 1000}, []int{0, 1, 2, 3}},
+		{"s empty", nil, []int{0, 1, 2}},
+		{"universe empty", []int{1, 2}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s, universe IntSet
+			s.AddAll(tt.elems...)
+			universe.AddAll(tt.universe...)
+			s.ComplementWithin(&universe)
+
+			inS := map[int]bool{}
+			for _, x := range tt.elems {
+				inS[x] = true
+			}
+			var want []int
+			for _, x := range tt.universe {
+				if !inS[x] {
+					want = append(want, x)
+				}
+			}
+			sort.Ints(want)
+
+			if got := s.String(); got != formatElems(want) {
+				t.Errorf("ComplementWithin(%v) of %v = %v, want %v", tt.universe, tt.elems, got, formatElems(want))
+			}
+		})
+	}
+}
+
+func TestIntSetCombinationCounts(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []int
+	}{
+		{"disjoint", []int{1, 2, 3}, []int{4, 5, 6}},
+		{"overlap", []int{1, 2, 3}, []int{2, 3, 4}},
+		{"a longer than b", []int{1, 1000}, []int{1}},
+		{"b longer than a", []int{1}, []int{1, 1000}},
+		{"empty a", nil, []int{1, 2}},
+		{"empty b", []int{1, 2}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s, u IntSet
+			s.AddAll(tt.a...)
+			u.AddAll(tt.b...)
+
+			if got, want := s.UnionCount(&u), s.Union(&u).Len(); got != want {
+				t.Errorf("UnionCount(%v, %v) = %d, want %d", tt.a, tt.b, got, want)
+			}
+			if got, want := s.IntersectionCount(&u), s.Intersection(&u).Len(); got != want {
+				t.Errorf("IntersectionCount(%v, %v) = %d, want %d", tt.a, tt.b, got, want)
+			}
+			if got, want := s.DifferenceCount(&u), s.Difference(&u).Len(); got != want {
+				t.Errorf("DifferenceCount(%v, %v) = %d, want %d", tt.a, tt.b, got, want)
+			}
+			if got, want := s.SymmetricDifferenceCount(&u), s.SymmetricDiff(&u).Len(); got != want {
+				t.Errorf("SymmetricDifferenceCount(%v, %v) = %d, want %d", tt.a, tt.b, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package intset
+
+import (
+	"bytes"
+	"fmt"
+	"math/bits"
+)
+
+// blockWords is the number of words packed into each block of a
+// SparseIntSet. It is small enough that a block fits in a couple of cache
+// lines, yet large enough to amortize the cost of chasing next/prev
+// pointers for densely-populated regions.
+const blockWords = 4
+
+// bitsPerBlock is the number of values covered by a single block.
+const bitsPerBlock = blockWords * N
+
+// A block holds the bits for [offset*bitsPerBlock, (offset+1)*bitsPerBlock)
+// and is unlinked from its SparseIntSet's offset-sorted list once all-zero.
+type block struct {
+	offset     int
+	bits       [blockWords]uint
+	prev, next *block
+}
+
+func (b *block) isEmpty() bool {
+	for _, w := range b.bits {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// A SparseIntSet is a set of small non-negative integers, like IntSet, but
+// backed by a sorted list of blocks rather than one long word slice, which
+// suits sets such as {5, 1000000}. Its zero value represents the empty set.
+type SparseIntSet struct {
+	head, tail *block
+}
+
+// Set is the interface satisfied by both IntSet and SparseIntSet, the two
+// set representations in this package. IntersectWith and DifferenceWith
+// accept any Set, falling back to Has for unfamiliar concrete types;
+// UnionWith only supports these two concrete types and panics otherwise.
+type Set interface {
+	Has(x int) bool
+	Add(x int)
+	Remove(x int)
+	Len() int
+	String() string
+	UnionWith(t Set)
+	IntersectWith(t Set)
+	DifferenceWith(t Set)
+}
+
+var _ Set = (*IntSet)(nil)
+var _ Set = (*SparseIntSet)(nil)
+
+// blockAt returns the block with the given offset, or nil if s has no such
+// block.
+func (s *SparseIntSet) blockAt(offset int) *block {
+	for b := s.head; b != nil && b.offset <= offset; b = b.next {
+		if b.offset == offset {
+			return b
+		}
+	}
+	return nil
+}
+
+// getBlock returns the block with the given offset, creating and splicing
+// it into the list in sorted order if it does not already exist.
+func (s *SparseIntSet) getBlock(offset int) *block {
+	b := s.head
+	for b != nil && b.offset < offset {
+		b = b.next
+	}
+	if b != nil && b.offset == offset {
+		return b
+	}
+	nb := &block{offset: offset}
+	s.insertBefore(nb, b)
+	return nb
+}
+
+// insertBefore splices nb into s's block list immediately before at. A nil
+// at means nb becomes the new tail.
+func (s *SparseIntSet) insertBefore(nb, at *block) {
+	if at == nil {
+		nb.prev = s.tail
+		if s.tail != nil {
+			s.tail.next = nb
+		} else {
+			s.head = nb
+		}
+		s.tail = nb
+		return
+	}
+	nb.next = at
+	nb.prev = at.prev
+	if at.prev != nil {
+		at.prev.next = nb
+	} else {
+		s.head = nb
+	}
+	at.prev = nb
+}
+
+// removeBlock unlinks b from s's block list.
+func (s *SparseIntSet) removeBlock(b *block) {
+	if b.prev != nil {
+		b.prev.next = b.next
+	} else {
+		s.head = b.next
+	}
+	if b.next != nil {
+		b.next.prev = b.prev
+	} else {
+		s.tail = b.prev
+	}
+}
+
+// Has reports whether the set contains the non-negative value x.
+func (s *SparseIntSet) Has(x int) bool {
+	b := s.blockAt(x / bitsPerBlock)
+	if b == nil {
+		return false
+	}
+	r := x % bitsPerBlock
+	return b.bits[r/N]&(1<<uint(r%N)) != 0
+}
+
+// Add adds the non-negative value x to the set.
+func (s *SparseIntSet) Add(x int) {
+	b := s.getBlock(x / bitsPerBlock)
+	r := x % bitsPerBlock
+	b.bits[r/N] |= 1 << uint(r%N)
+}
+
+// Remove removes x from the set.
+func (s *SparseIntSet) Remove(x int) {
+	b := s.blockAt(x / bitsPerBlock)
+	if b == nil {
+		return
+	}
+	r := x % bitsPerBlock
+	b.bits[r/N] &^= 1 << uint(r%N)
+	if b.isEmpty() {
+		s.removeBlock(b)
+	}
+}
+
+// UnionWith sets s to the union of s and t. Unlike IntersectWith and
+// DifferenceWith, it only supports t being a *SparseIntSet or *IntSet —
+// the two concrete types in this package — and panics for any other Set.
+func (s *SparseIntSet) UnionWith(t Set) {
+	switch tt := t.(type) {
+	case *SparseIntSet:
+		sb, tb := s.head, tt.head
+		for tb != nil {
+			for sb != nil && sb.offset < tb.offset {
+				sb = sb.next
+			}
+			if sb != nil && sb.offset == tb.offset {
+				for i, w := range tb.bits {
+					sb.bits[i] |= w
+				}
+			} else {
+				nb := &block{offset: tb.offset, bits: tb.bits}
+				s.insertBefore(nb, sb)
+			}
+			tb = tb.next
+		}
+	case *IntSet:
+		for i, word := range tt.words {
+			for j := 0; j < N; j++ {
+				if word&(1<<uint(j)) != 0 {
+					s.Add(i*N + j)
+				}
+			}
+		}
+	default:
+		panic(fmt.Sprintf("intset: SparseIntSet.UnionWith: unsupported Set implementation %T", t))
+	}
+}
+
+// IntersectWith sets s to the intersection of s and t. When t is a
+// *SparseIntSet, the two sorted block lists are merged in a single pass;
+// t may also be any other Set, tested via t.Has.
+func (s *SparseIntSet) IntersectWith(t Set) {
+	tt, ok := t.(*SparseIntSet)
+	if !ok {
+		for b := s.head; b != nil; {
+			next := b.next
+			for i := range b.bits {
+				for j := 0; j < N; j++ {
+					x := b.offset*bitsPerBlock + i*N + j
+					if b.bits[i]&(1<<uint(j)) != 0 && !t.Has(x) {
+						b.bits[i] &^= 1 << uint(j)
+					}
+				}
+			}
+			if b.isEmpty() {
+				s.removeBlock(b)
+			}
+			b = next
+		}
+		return
+	}
+	sb, tb := s.head, tt.head
+	for sb != nil && tb != nil {
+		switch {
+		case sb.offset < tb.offset:
+			next := sb.next
+			s.removeBlock(sb)
+			sb = next
+		case sb.offset > tb.offset:
+			tb = tb.next
+		default:
+			for i := range sb.bits {
+				sb.bits[i] &= tb.bits[i]
+			}
+			next := sb.next
+			if sb.isEmpty() {
+				s.removeBlock(sb)
+			}
+			sb, tb = next, tb.next
+		}
+	}
+	for sb != nil {
+		next := sb.next
+		s.removeBlock(sb)
+		sb = next
+	}
+}
+
+// DifferenceWith sets s to the difference of s and t. When t is a
+// *SparseIntSet, the two sorted block lists are merged in a single linear
+// pass; t may also be any other Set, tested via t.Has.
+func (s *SparseIntSet) DifferenceWith(t Set) {
+	tt, ok := t.(*SparseIntSet)
+	if !ok {
+		for b := s.head; b != nil; {
+			next := b.next
+			for i := range b.bits {
+				for j := 0; j < N; j++ {
+					x := b.offset*bitsPerBlock + i*N + j
+					if b.bits[i]&(1<<uint(j)) != 0 && t.Has(x) {
+						b.bits[i] &^= 1 << uint(j)
+					}
+				}
+			}
+			if b.isEmpty() {
+				s.removeBlock(b)
+			}
+			b = next
+		}
+		return
+	}
+	sb, tb := s.head, tt.head
+	for sb != nil && tb != nil {
+		switch {
+		case sb.offset < tb.offset:
+			sb = sb.next
+		case sb.offset > tb.offset:
+			tb = tb.next
+		default:
+			for i := range sb.bits {
+				sb.bits[i] &^= tb.bits[i]
+			}
+			next := sb.next
+			if sb.isEmpty() {
+				s.removeBlock(sb)
+			}
+			sb, tb = next, tb.next
+		}
+	}
+}
+
+// IsEmpty reports whether the set has no elements.
+func (s *SparseIntSet) IsEmpty() bool {
+	return s.head == nil
+}
+
+// Min returns the smallest element of the set and reports whether the set
+// was non-empty. It runs in time proportional to a single block, not the
+// whole set, since the head block always holds the smallest elements.
+func (s *SparseIntSet) Min() (int, bool) {
+	if s.head == nil {
+		return 0, false
+	}
+	b := s.head
+	for i, w := range b.bits {
+		if w != 0 {
+			return b.offset*bitsPerBlock + i*N + bits.TrailingZeros(w), true
+		}
+	}
+	panic("intset: non-empty block contains no set bits")
+}
+
+// Max returns the largest element of the set and reports whether the set
+// was non-empty. Like Min, it only ever inspects one block.
+func (s *SparseIntSet) Max() (int, bool) {
+	if s.tail == nil {
+		return 0, false
+	}
+	b := s.tail
+	for i := blockWords - 1; i >= 0; i-- {
+		if w := b.bits[i]; w != 0 {
+			return b.offset*bitsPerBlock + i*N + bits.Len(w) - 1, true
+		}
+	}
+	panic("intset: non-empty block contains no set bits")
+}
+
+// TakeMin removes and returns the smallest element of the set, reporting
+// whether the set was non-empty.
+func (s *SparseIntSet) TakeMin() (int, bool) {
+	x, ok := s.Min()
+	if ok {
+		s.Remove(x)
+	}
+	return x, ok
+}
+
+// Len returns the number of elements in the set.
+func (s *SparseIntSet) Len() int {
+	n := 0
+	for b := s.head; b != nil; b = b.next {
+		for _, w := range b.bits {
+			n += bits.OnesCount(w)
+		}
+	}
+	return n
+}
+
+// String returns the set as a string of the form "{1 2 3}".
+func (s *SparseIntSet) String() string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for b := s.head; b != nil; b = b.next {
+		for i, w := range b.bits {
+			for w != 0 {
+				j := bits.TrailingZeros(w)
+				w &= w - 1
+				if buf.Len() > len("{") {
+					buf.WriteByte(' ')
+				}
+				fmt.Fprintf(&buf, "%d", b.offset*bitsPerBlock+i*N+j)
+			}
+		}
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
@@ -0,0 +1,208 @@
+package intset
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// sparseElems returns the elements of a SparseIntSet in ascending order,
+// read directly off the block list so the test doesn't depend on any
+// SparseIntSet method under test.
+func sparseElems(s *SparseIntSet) []int {
+	var res []int
+	for b := s.head; b != nil; b = b.next {
+		for i, w := range b.bits {
+			for j := 0; j < N; j++ {
+				if w&(1<<uint(j)) != 0 {
+					res = append(res, b.offset*bitsPerBlock+i*N+j)
+				}
+			}
+		}
+	}
+	return res
+}
+
+func newSparse(xs ...int) *SparseIntSet {
+	s := &SparseIntSet{}
+	for _, x := range xs {
+		s.Add(x)
+	}
+	return s
+}
+
+// refUnion, refIntersection and refDifference are reference
+// implementations over plain int slices, used to check SparseIntSet's
+// word-parallel, block-merging versions.
+func refUnion(a, b []int) []int {
+	seen := map[int]bool{}
+	for _, x := range a {
+		seen[x] = true
+	}
+	for _, x := range b {
+		seen[x] = true
+	}
+	return sortedKeys(seen)
+}
+
+func refIntersection(a, b []int) []int {
+	inB := map[int]bool{}
+	for _, x := range b {
+		inB[x] = true
+	}
+	seen := map[int]bool{}
+	for _, x := range a {
+		if inB[x] {
+			seen[x] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+func refDifference(a, b []int) []int {
+	inB := map[int]bool{}
+	for _, x := range b {
+		inB[x] = true
+	}
+	seen := map[int]bool{}
+	for _, x := range a {
+		if !inB[x] {
+			seen[x] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+func sortedKeys(m map[int]bool) []int {
+	res := make([]int, 0, len(m))
+	for x := range m {
+		res = append(res, x)
+	}
+	sort.Ints(res)
+	return res
+}
+
+func TestSparseIntSetUnionIntersectDifference(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []int
+	}{
+		{"disjoint", []int{1, 2, 3}, []int{4, 5, 6}},
+		{"overlap within a block", []int{1, 2, 3, 4}, []int{3, 4, 5, 6}},
+		{"overlap across far-apart blocks", []int{1, 2, bitsPerBlock * 10, bitsPerBlock*10 + 1}, []int{2, bitsPerBlock * 10, 1000000}},
+		{"empty a", nil, []int{1, 2, 3}},
+		{"empty b", []int{1, 2, 3}, nil},
+		{"identical", []int{1, 100, 10000}, []int{1, 100, 10000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := sparseElems(newSparse(tt.a...).union(tt.b)), refUnion(tt.a, tt.b); !equalInts(got, want) {
+				t.Errorf("UnionWith(%v, %v) = %v, want %v", tt.a, tt.b, got, want)
+			}
+			if got, want := sparseElems(newSparse(tt.a...).intersect(tt.b)), refIntersection(tt.a, tt.b); !equalInts(got, want) {
+				t.Errorf("IntersectWith(%v, %v) = %v, want %v", tt.a, tt.b, got, want)
+			}
+			if got, want := sparseElems(newSparse(tt.a...).difference(tt.b)), refDifference(tt.a, tt.b); !equalInts(got, want) {
+				t.Errorf("DifferenceWith(%v, %v) = %v, want %v", tt.a, tt.b, got, want)
+			}
+		})
+	}
+}
+
+func (s *SparseIntSet) union(xs []int) *SparseIntSet {
+	s.UnionWith(newSparse(xs...))
+	return s
+}
+
+func (s *SparseIntSet) intersect(xs []int) *SparseIntSet {
+	s.IntersectWith(newSparse(xs...))
+	return s
+}
+
+func (s *SparseIntSet) difference(xs []int) *SparseIntSet {
+	s.DifferenceWith(newSparse(xs...))
+	return s
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSparseIntSetTakeMinDrainOrder(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	want := map[int]bool{}
+	s := &SparseIntSet{}
+	for i := 0; i < 500; i++ {
+		x := rng.Intn(10_000_000)
+		want[x] = true
+		s.Add(x)
+	}
+
+	var got []int
+	for {
+		x, ok := s.TakeMin()
+		if !ok {
+			break
+		}
+		got = append(got, x)
+	}
+
+	if !s.IsEmpty() {
+		t.Fatalf("set not empty after draining via TakeMin")
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i-1] >= got[i] {
+			t.Fatalf("TakeMin returned elements out of order: %d before %d", got[i-1], got[i])
+		}
+	}
+	wantSorted := make([]int, 0, len(want))
+	for x := range want {
+		wantSorted = append(wantSorted, x)
+	}
+	sort.Ints(wantSorted)
+	if !equalInts(got, wantSorted) {
+		t.Fatalf("TakeMin drained %v, want %v", got, wantSorted)
+	}
+}
+
+// BenchmarkDense and BenchmarkSparse add the same number of elements at
+// increasing spread, to show the crossover point where SparseIntSet's
+// block list wins over IntSet's dense word slice: at spread=1 the values
+// are packed into a handful of words and the dense slice wins, but as the
+// spread grows into the millions, IntSet has to allocate a word for every
+// gap while SparseIntSet only allocates a block per populated region.
+
+func BenchmarkDense(b *testing.B) {
+	for _, spread := range []int{1, 1000, 1000000} {
+		b.Run(fmt.Sprintf("spread=%d", spread), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var s IntSet
+				for j := 0; j < 1000; j++ {
+					s.Add(j * spread)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSparse(b *testing.B) {
+	for _, spread := range []int{1, 1000, 1000000} {
+		b.Run(fmt.Sprintf("spread=%d", spread), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var s SparseIntSet
+				for j := 0; j < 1000; j++ {
+					s.Add(j * spread)
+				}
+			}
+		})
+	}
+}